@@ -0,0 +1,236 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Auth applies credentials to outbound requests. Implementations may watch
+// their backing credential file and hot-reload, so long-running scans
+// survive a rotated token or cookie jar without restarting.
+type Auth interface {
+	Apply(req *http.Request) error
+	Reload() error
+	Stop()
+}
+
+// ParseAuth builds an Auth provider from a --auth flag value, one of:
+//
+//	basic://user:pass
+//	bearer:file:///path/to/token
+//	cookie-jar:file:///path/to/cookies.txt
+//	htpasswd-check://user:pass
+func ParseAuth(spec string) (Auth, error) {
+	parts := strings.SplitN(spec, "://", 2)
+	if len(parts) != 2 {
+		return nil, fmt.Errorf("auth: malformed spec %q, expected scheme://rest", spec)
+	}
+	scheme, rest := parts[0], parts[1]
+
+	switch scheme {
+	case "basic", "htpasswd-check":
+		cred := strings.SplitN(rest, ":", 2)
+		if len(cred) != 2 {
+			return nil, fmt.Errorf("auth: %s spec must be user:pass", scheme)
+		}
+		return &basicAuth{user: cred[0], pass: cred[1]}, nil
+	case "bearer:file":
+		return newBearerAuth(rest)
+	case "cookie-jar:file":
+		return newCookieJarAuth(rest)
+	default:
+		return nil, fmt.Errorf("auth: unknown scheme %q", scheme)
+	}
+}
+
+// basicAuth sends a static username/password as HTTP Basic credentials.
+// It covers both plain -auth basic:// targets and targets whose basic
+// auth happens to be backed by an htpasswd file server-side.
+type basicAuth struct {
+	user, pass string
+}
+
+func (a *basicAuth) Apply(req *http.Request) error {
+	req.SetBasicAuth(a.user, a.pass)
+	return nil
+}
+
+func (a *basicAuth) Reload() error { return nil }
+func (a *basicAuth) Stop()         {}
+
+// fileReloader polls a credential file's mtime and invokes reloadFn
+// whenever it changes, so a rotated token or cookie jar is picked up
+// without killing the scan.
+type fileReloader struct {
+	path     string
+	mtime    time.Time
+	reloadFn func() error
+	stopCh   chan struct{}
+}
+
+func newFileReloader(path string, reloadFn func() error) *fileReloader {
+	fr := &fileReloader{path: path, reloadFn: reloadFn, stopCh: make(chan struct{})}
+	if info, err := os.Stat(path); err == nil {
+		fr.mtime = info.ModTime()
+	}
+	go fr.watch()
+	return fr
+}
+
+func (fr *fileReloader) watch() {
+	ticker := time.NewTicker(5 * time.Second)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			info, err := os.Stat(fr.path)
+			if err != nil || !info.ModTime().After(fr.mtime) {
+				continue
+			}
+			if err := fr.reloadFn(); err == nil {
+				fr.mtime = info.ModTime()
+			}
+		case <-fr.stopCh:
+			return
+		}
+	}
+}
+
+func (fr *fileReloader) Stop() {
+	close(fr.stopCh)
+}
+
+// bearerAuth reads a bearer token from a file and sends it as an
+// Authorization header, hot-reloading when the file's mtime changes.
+type bearerAuth struct {
+	mu      sync.RWMutex
+	token   string
+	path    string
+	watcher *fileReloader
+}
+
+func newBearerAuth(path string) (*bearerAuth, error) {
+	a := &bearerAuth{path: path}
+	if err := a.Reload(); err != nil {
+		return nil, err
+	}
+	a.watcher = newFileReloader(path, a.Reload)
+	return a, nil
+}
+
+func (a *bearerAuth) Reload() error {
+	data, err := os.ReadFile(a.path)
+	if err != nil {
+		return err
+	}
+	a.mu.Lock()
+	a.token = strings.TrimSpace(string(data))
+	a.mu.Unlock()
+	return nil
+}
+
+func (a *bearerAuth) Apply(req *http.Request) error {
+	a.mu.RLock()
+	token := a.token
+	a.mu.RUnlock()
+	req.Header.Set("Authorization", "Bearer "+token)
+	return nil
+}
+
+func (a *bearerAuth) Stop() {
+	a.watcher.Stop()
+}
+
+type netscapeCookie struct {
+	domain string
+	path   string
+	name   string
+	value  string
+}
+
+// cookieJarAuth loads a Netscape-format cookie file and attaches the
+// cookies matching each request's host as a Cookie header, hot-reloading
+// when the jar file changes.
+type cookieJarAuth struct {
+	mu      sync.RWMutex
+	cookies []netscapeCookie
+	path    string
+	watcher *fileReloader
+}
+
+func newCookieJarAuth(path string) (*cookieJarAuth, error) {
+	a := &cookieJarAuth{path: path}
+	if err := a.Reload(); err != nil {
+		return nil, err
+	}
+	a.watcher = newFileReloader(path, a.Reload)
+	return a, nil
+}
+
+func (a *cookieJarAuth) Reload() error {
+	f, err := os.Open(a.path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	var cookies []netscapeCookie
+	sc := bufio.NewScanner(f)
+	for sc.Scan() {
+		line := sc.Text()
+		if strings.TrimSpace(line) == "" || strings.HasPrefix(strings.TrimSpace(line), "#") {
+			continue
+		}
+		fields := strings.Split(line, "\t")
+		if len(fields) < 7 {
+			continue
+		}
+		cookies = append(cookies, netscapeCookie{
+			domain: fields[0],
+			path:   fields[2],
+			name:   fields[5],
+			value:  fields[6],
+		})
+	}
+	if err := sc.Err(); err != nil {
+		return err
+	}
+
+	a.mu.Lock()
+	a.cookies = cookies
+	a.mu.Unlock()
+	return nil
+}
+
+func (a *cookieJarAuth) Apply(req *http.Request) error {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+
+	host := req.URL.Hostname()
+	var parts []string
+	for _, c := range a.cookies {
+		if !cookieDomainMatches(c.domain, host) {
+			continue
+		}
+		parts = append(parts, c.name+"="+c.value)
+	}
+	if len(parts) > 0 {
+		req.Header.Set("Cookie", strings.Join(parts, "; "))
+	}
+	return nil
+}
+
+func (a *cookieJarAuth) Stop() {
+	a.watcher.Stop()
+}
+
+func cookieDomainMatches(cookieDomain, host string) bool {
+	cookieDomain = strings.TrimPrefix(cookieDomain, ".")
+	return host == cookieDomain || strings.HasSuffix(host, "."+cookieDomain)
+}
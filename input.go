@@ -0,0 +1,149 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+)
+
+// harFile is the subset of the HAR 1.2 format (--input-har) we care
+// about: each entry's request method, URL, headers and POST body.
+type harFile struct {
+	Log struct {
+		Entries []struct {
+			Request struct {
+				Method  string `json:"method"`
+				URL     string `json:"url"`
+				Headers []struct {
+					Name  string `json:"name"`
+					Value string `json:"value"`
+				} `json:"headers"`
+				PostData struct {
+					MimeType string `json:"mimeType"`
+					Text     string `json:"text"`
+				} `json:"postData"`
+			} `json:"request"`
+		} `json:"entries"`
+	} `json:"log"`
+}
+
+// LoadHARProbes reads a HAR file and builds one Probe per captured
+// request, preserving its method, headers/cookies and body.
+func LoadHARProbes(path string) ([]Probe, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var har harFile
+	if err := json.Unmarshal(data, &har); err != nil {
+		return nil, fmt.Errorf("har: %w", err)
+	}
+
+	var probes []Probe
+	for _, entry := range har.Log.Entries {
+		header := http.Header{}
+		for _, h := range entry.Request.Headers {
+			header.Add(h.Name, h.Value)
+		}
+		if entry.Request.PostData.MimeType != "" && header.Get("Content-Type") == "" {
+			header.Set("Content-Type", entry.Request.PostData.MimeType)
+		}
+
+		probes = append(probes, newCapturedProbe(capturedRequest{
+			Method: entry.Request.Method,
+			URL:    entry.Request.URL,
+			Header: header,
+			Body:   []byte(entry.Request.PostData.Text),
+		}))
+	}
+
+	return probes, nil
+}
+
+// burpXML is the subset of a Burp Suite "Save items" XML export
+// (--input-burp) we care about: each item's original URL and its full
+// raw HTTP request (optionally base64-encoded).
+type burpXML struct {
+	Items []struct {
+		URL     string `xml:"url"`
+		Request struct {
+			Base64 string `xml:"base64,attr"`
+			Value  string `xml:",chardata"`
+		} `xml:"request"`
+	} `xml:"item"`
+}
+
+// LoadBurpProbes reads a Burp XML export and builds one Probe per
+// captured request, preserving its method, headers/cookies and body.
+func LoadBurpProbes(path string) ([]Probe, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var doc burpXML
+	if err := xml.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("burp: %w", err)
+	}
+
+	var probes []Probe
+	for _, item := range doc.Items {
+		raw := item.Request.Value
+		if strings.EqualFold(item.Request.Base64, "true") {
+			decoded, err := base64.StdEncoding.DecodeString(strings.TrimSpace(raw))
+			if err != nil {
+				continue
+			}
+			raw = string(decoded)
+		}
+
+		cr, err := parseBurpRawRequest([]byte(raw), item.URL)
+		if err != nil {
+			continue
+		}
+		probes = append(probes, newCapturedProbe(cr))
+	}
+
+	return probes, nil
+}
+
+// parseBurpRawRequest parses a full raw HTTP request (request line,
+// headers, body) and combines it with the item's original absolute URL
+// to recover scheme and host, which the raw request line usually lacks.
+func parseBurpRawRequest(raw []byte, fallbackURL string) (capturedRequest, error) {
+	req, err := http.ReadRequest(bufio.NewReader(bytes.NewReader(raw)))
+	if err != nil {
+		return capturedRequest{}, err
+	}
+	defer req.Body.Close()
+
+	body, err := io.ReadAll(req.Body)
+	if err != nil {
+		return capturedRequest{}, err
+	}
+
+	fullURL := req.URL.String()
+	if base, err := url.Parse(fallbackURL); err == nil && base.IsAbs() {
+		base.Path = req.URL.Path
+		base.RawQuery = req.URL.RawQuery
+		fullURL = base.String()
+	} else if host := req.Header.Get("Host"); host != "" {
+		fullURL = "http://" + host + req.URL.RequestURI()
+	}
+
+	return capturedRequest{
+		Method: req.Method,
+		URL:    fullURL,
+		Header: req.Header,
+		Body:   body,
+	}, nil
+}
@@ -0,0 +1,72 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// walkJSON collects every leaf scalar in a decoded JSON document as a
+// dotted path (object keys and array indices joined by '.') to its
+// stringified value.
+func walkJSON(prefix string, v interface{}, out map[string]string) {
+	switch vv := v.(type) {
+	case map[string]interface{}:
+		for k, val := range vv {
+			walkJSON(joinJSONPath(prefix, k), val, out)
+		}
+	case []interface{}:
+		for i, val := range vv {
+			walkJSON(joinJSONPath(prefix, strconv.Itoa(i)), val, out)
+		}
+	case string:
+		out[prefix] = vv
+	case float64, bool:
+		out[prefix] = fmt.Sprintf("%v", vv)
+	}
+}
+
+func joinJSONPath(prefix, key string) string {
+	if prefix == "" {
+		return key
+	}
+	return prefix + "." + key
+}
+
+// setJSONPath walks a decoded JSON document along a dotted path (as
+// produced by walkJSON) and overwrites the leaf it finds with value.
+func setJSONPath(node interface{}, path string, value string) error {
+	return setJSONPathParts(node, strings.Split(path, "."), value)
+}
+
+func setJSONPathParts(node interface{}, parts []string, value string) error {
+	if len(parts) == 0 {
+		return fmt.Errorf("jsonpath: empty path")
+	}
+	key := parts[0]
+
+	switch n := node.(type) {
+	case map[string]interface{}:
+		if len(parts) == 1 {
+			n[key] = value
+			return nil
+		}
+		child, ok := n[key]
+		if !ok {
+			return fmt.Errorf("jsonpath: no key %q", key)
+		}
+		return setJSONPathParts(child, parts[1:], value)
+	case []interface{}:
+		idx, err := strconv.Atoi(key)
+		if err != nil || idx < 0 || idx >= len(n) {
+			return fmt.Errorf("jsonpath: bad index %q", key)
+		}
+		if len(parts) == 1 {
+			n[idx] = value
+			return nil
+		}
+		return setJSONPathParts(n[idx], parts[1:], value)
+	default:
+		return fmt.Errorf("jsonpath: cannot descend into %T at %q", node, key)
+	}
+}
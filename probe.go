@@ -0,0 +1,206 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// Probe builds the requests needed to test a single target (a plain URL
+// from stdin, or a captured request from a HAR/Burp export) for
+// reflection and open-redirect bugs.
+type Probe interface {
+	// Key identifies the underlying request for reporting and state.
+	Key() string
+	// Target is the request's URL, used to expand payload template
+	// variables like {host} and {scheme}.
+	Target() *url.URL
+	// Params returns the current value of every query/body parameter
+	// this probe can mutate.
+	Params() map[string]string
+	// Baseline returns the request as originally given, unmodified.
+	Baseline() (*http.Request, error)
+	// Build returns a fresh request with param set to value.
+	Build(param, value string) (*http.Request, error)
+}
+
+// urlProbe is a plain URL read from stdin: GET, query parameters only.
+type urlProbe struct {
+	rawURL string
+}
+
+func (p *urlProbe) Key() string { return p.rawURL }
+
+func (p *urlProbe) Target() *url.URL {
+	u, _ := url.Parse(p.rawURL)
+	return u
+}
+
+func (p *urlProbe) Params() map[string]string {
+	u, err := url.Parse(p.rawURL)
+	if err != nil {
+		return nil
+	}
+	params := make(map[string]string)
+	for k, vv := range u.Query() {
+		if len(vv) > 0 {
+			params[k] = vv[0]
+		}
+	}
+	return params
+}
+
+func (p *urlProbe) Baseline() (*http.Request, error) {
+	return http.NewRequest("GET", p.rawURL, nil)
+}
+
+func (p *urlProbe) Build(param, value string) (*http.Request, error) {
+	u, err := url.Parse(p.rawURL)
+	if err != nil {
+		return nil, err
+	}
+	qs := u.Query()
+	qs.Set(param, value)
+	u.RawQuery = qs.Encode()
+	return http.NewRequest("GET", u.String(), nil)
+}
+
+// capturedRequest is a request captured by a proxy (HAR or Burp XML),
+// preserving its original method, headers/cookies and body.
+type capturedRequest struct {
+	Method string
+	URL    string
+	Header http.Header
+	Body   []byte
+}
+
+// capturedProbe mutates a capturedRequest's query parameters, form body
+// parameters or JSON body parameters (addressed by a "query.", "form."
+// or "json." prefixed name), while preserving everything else.
+type capturedProbe struct {
+	req capturedRequest
+}
+
+func newCapturedProbe(req capturedRequest) *capturedProbe {
+	return &capturedProbe{req: req}
+}
+
+func (p *capturedProbe) Key() string {
+	return fmt.Sprintf("%s %s", p.req.Method, p.req.URL)
+}
+
+func (p *capturedProbe) Target() *url.URL {
+	u, _ := url.Parse(p.req.URL)
+	return u
+}
+
+func (p *capturedProbe) Params() map[string]string {
+	params := make(map[string]string)
+
+	if u, err := url.Parse(p.req.URL); err == nil {
+		for k, vv := range u.Query() {
+			if len(vv) > 0 {
+				params["query."+k] = vv[0]
+			}
+		}
+	}
+
+	ct := p.req.Header.Get("Content-Type")
+	switch {
+	case strings.Contains(ct, "application/x-www-form-urlencoded"):
+		if vals, err := url.ParseQuery(string(p.req.Body)); err == nil {
+			for k, vv := range vals {
+				if len(vv) > 0 {
+					params["form."+k] = vv[0]
+				}
+			}
+		}
+	case strings.Contains(ct, "json"):
+		var doc interface{}
+		if err := json.Unmarshal(p.req.Body, &doc); err == nil {
+			leaves := make(map[string]string)
+			walkJSON("", doc, leaves)
+			for path, v := range leaves {
+				params["json."+path] = v
+			}
+		}
+	}
+
+	return params
+}
+
+func (p *capturedProbe) Baseline() (*http.Request, error) {
+	return p.newRequest(p.req.URL, p.req.Body)
+}
+
+func (p *capturedProbe) Build(param, value string) (*http.Request, error) {
+	switch {
+	case strings.HasPrefix(param, "query."):
+		return p.buildQuery(strings.TrimPrefix(param, "query."), value)
+	case strings.HasPrefix(param, "form."):
+		return p.buildForm(strings.TrimPrefix(param, "form."), value)
+	case strings.HasPrefix(param, "json."):
+		return p.buildJSON(strings.TrimPrefix(param, "json."), value)
+	default:
+		return nil, fmt.Errorf("capturedProbe: unknown param %q", param)
+	}
+}
+
+func (p *capturedProbe) buildQuery(key, value string) (*http.Request, error) {
+	u, err := url.Parse(p.req.URL)
+	if err != nil {
+		return nil, err
+	}
+	qs := u.Query()
+	qs.Set(key, value)
+	u.RawQuery = qs.Encode()
+	return p.newRequest(u.String(), p.req.Body)
+}
+
+func (p *capturedProbe) buildForm(key, value string) (*http.Request, error) {
+	vals, err := url.ParseQuery(string(p.req.Body))
+	if err != nil {
+		return nil, err
+	}
+	vals.Set(key, value)
+	return p.newRequest(p.req.URL, []byte(vals.Encode()))
+}
+
+func (p *capturedProbe) buildJSON(path, value string) (*http.Request, error) {
+	var doc interface{}
+	if err := json.Unmarshal(p.req.Body, &doc); err != nil {
+		return nil, err
+	}
+	if err := setJSONPath(doc, path, value); err != nil {
+		return nil, err
+	}
+	data, err := json.Marshal(doc)
+	if err != nil {
+		return nil, err
+	}
+	return p.newRequest(p.req.URL, data)
+}
+
+func (p *capturedProbe) newRequest(rawURL string, body []byte) (*http.Request, error) {
+	var bodyReader io.Reader
+	if len(body) > 0 {
+		bodyReader = bytes.NewReader(body)
+	}
+
+	req, err := http.NewRequest(p.req.Method, rawURL, bodyReader)
+	if err != nil {
+		return nil, err
+	}
+
+	for k, vv := range p.req.Header {
+		for _, v := range vv {
+			req.Header.Add(k, v)
+		}
+	}
+
+	return req, nil
+}
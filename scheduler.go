@@ -0,0 +1,260 @@
+package main
+
+import (
+	"fmt"
+	"math/rand"
+	"net"
+	"net/http"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	defaultMaxRetries           = 3
+	defaultCircuitBreakFailures = 5
+	defaultCircuitBreakCooldown = 30 * time.Second
+	backoffBase                 = 200 * time.Millisecond
+	backoffCap                  = 30 * time.Second
+)
+
+// Scheduler gates outbound requests behind a global rate limit and a
+// per-host concurrency cap, retries transient failures with jittered
+// backoff, and circuit-breaks hosts that keep failing.
+type Scheduler struct {
+	limiter            *rateLimiter
+	perHostConcurrency int
+	maxRetries         int
+	breakFailures      int
+	breakCooldown      time.Duration
+
+	hostSemsMu sync.Mutex
+	hostSems   map[string]chan struct{}
+
+	breakersMu sync.Mutex
+	breakers   map[string]*hostBreaker
+
+	stats schedulerStats
+}
+
+type hostBreaker struct {
+	mu        sync.Mutex
+	failures  int
+	openUntil time.Time
+}
+
+type schedulerStats struct {
+	start         time.Time
+	requests      uint64
+	retries       uint64
+	circuitBreaks uint64
+}
+
+// NewScheduler builds a Scheduler. rps <= 0 means no global rate limit.
+func NewScheduler(rps float64, perHostConcurrency int) *Scheduler {
+	if perHostConcurrency <= 0 {
+		perHostConcurrency = 4
+	}
+	return &Scheduler{
+		limiter:            newRateLimiter(rps),
+		perHostConcurrency: perHostConcurrency,
+		maxRetries:         defaultMaxRetries,
+		breakFailures:      defaultCircuitBreakFailures,
+		breakCooldown:      defaultCircuitBreakCooldown,
+		hostSems:           make(map[string]chan struct{}),
+		breakers:           make(map[string]*hostBreaker),
+		stats:              schedulerStats{start: time.Now()},
+	}
+}
+
+// Do executes req through the scheduler: rate limited, capped per host,
+// retried with backoff on timeouts and 429/503, and circuit-broken after
+// too many consecutive failures against the same host.
+func (s *Scheduler) Do(req *http.Request) (*http.Response, error) {
+	host := req.URL.Hostname()
+
+	if !s.breakerAllows(host) {
+		return nil, fmt.Errorf("scheduler: circuit open for host %s", host)
+	}
+
+	sem := s.hostSemaphore(host)
+	sem <- struct{}{}
+	defer func() { <-sem }()
+
+	var resp *http.Response
+	var err error
+
+	for attempt := 0; attempt <= s.maxRetries; attempt++ {
+		s.limiter.Wait()
+
+		resp, err = httpClient.Do(req)
+		atomic.AddUint64(&s.stats.requests, 1)
+
+		if err == nil && resp.StatusCode != http.StatusTooManyRequests && resp.StatusCode != http.StatusServiceUnavailable {
+			s.breakerSucceeded(host)
+			return resp, nil
+		}
+
+		if !isRetryable(err, resp) || attempt == s.maxRetries {
+			if resp != nil {
+				resp.Body.Close()
+			}
+			break
+		}
+
+		wait := retryAfter(resp)
+		if wait <= 0 {
+			wait = fullJitterBackoff(attempt)
+		}
+		if resp != nil {
+			resp.Body.Close()
+		}
+		atomic.AddUint64(&s.stats.retries, 1)
+		time.Sleep(wait)
+	}
+
+	s.breakerFailed(host)
+	if err == nil {
+		err = fmt.Errorf("scheduler: giving up on %s after %d attempts, last status %d", req.URL, s.maxRetries+1, resp.StatusCode)
+	}
+	return nil, err
+}
+
+func (s *Scheduler) hostSemaphore(host string) chan struct{} {
+	s.hostSemsMu.Lock()
+	defer s.hostSemsMu.Unlock()
+
+	sem, ok := s.hostSems[host]
+	if !ok {
+		sem = make(chan struct{}, s.perHostConcurrency)
+		s.hostSems[host] = sem
+	}
+	return sem
+}
+
+func (s *Scheduler) breaker(host string) *hostBreaker {
+	s.breakersMu.Lock()
+	defer s.breakersMu.Unlock()
+
+	b, ok := s.breakers[host]
+	if !ok {
+		b = &hostBreaker{}
+		s.breakers[host] = b
+	}
+	return b
+}
+
+func (s *Scheduler) breakerAllows(host string) bool {
+	b := s.breaker(host)
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.openUntil.IsZero() || time.Now().After(b.openUntil)
+}
+
+func (s *Scheduler) breakerSucceeded(host string) {
+	b := s.breaker(host)
+	b.mu.Lock()
+	b.failures = 0
+	b.openUntil = time.Time{}
+	b.mu.Unlock()
+}
+
+func (s *Scheduler) breakerFailed(host string) {
+	b := s.breaker(host)
+	b.mu.Lock()
+	b.failures++
+	if b.failures >= s.breakFailures {
+		b.openUntil = time.Now().Add(s.breakCooldown)
+		b.failures = 0
+		atomic.AddUint64(&s.stats.circuitBreaks, 1)
+	}
+	b.mu.Unlock()
+}
+
+// StatsHandler serves the scheduler's running metrics for --stats-addr.
+func (s *Scheduler) StatsHandler(w http.ResponseWriter, r *http.Request) {
+	elapsed := time.Since(s.stats.start).Seconds()
+	requests := atomic.LoadUint64(&s.stats.requests)
+	rps := 0.0
+	if elapsed > 0 {
+		rps = float64(requests) / elapsed
+	}
+	fmt.Fprintf(w, "requests_total %d\n", requests)
+	fmt.Fprintf(w, "requests_per_sec %.2f\n", rps)
+	fmt.Fprintf(w, "retries_total %d\n", atomic.LoadUint64(&s.stats.retries))
+	fmt.Fprintf(w, "host_circuit_breaks_total %d\n", atomic.LoadUint64(&s.stats.circuitBreaks))
+}
+
+func isRetryable(err error, resp *http.Response) bool {
+	if err != nil {
+		if netErr, ok := err.(net.Error); ok {
+			return netErr.Timeout()
+		}
+		return false
+	}
+	return resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode == http.StatusServiceUnavailable
+}
+
+// retryAfter honors a Retry-After header, whether given in seconds or as
+// an HTTP date.
+func retryAfter(resp *http.Response) time.Duration {
+	if resp == nil {
+		return 0
+	}
+	v := resp.Header.Get("Retry-After")
+	if v == "" {
+		return 0
+	}
+	if secs, err := strconv.Atoi(v); err == nil {
+		return time.Duration(secs) * time.Second
+	}
+	if t, err := http.ParseTime(v); err == nil {
+		if d := time.Until(t); d > 0 {
+			return d
+		}
+	}
+	return 0
+}
+
+// fullJitterBackoff implements sleep = rand() * min(cap, base*2^attempt).
+func fullJitterBackoff(attempt int) time.Duration {
+	max := backoffBase * time.Duration(1<<uint(attempt))
+	if max > backoffCap {
+		max = backoffCap
+	}
+	return time.Duration(rand.Float64() * float64(max))
+}
+
+// rateLimiter is a simple token-bucket global rate limit. A nil
+// *rateLimiter means unlimited.
+type rateLimiter struct {
+	tokens chan struct{}
+}
+
+func newRateLimiter(rps float64) *rateLimiter {
+	if rps <= 0 {
+		return nil
+	}
+
+	rl := &rateLimiter{tokens: make(chan struct{}, 1)}
+	interval := time.Duration(float64(time.Second) / rps)
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for range ticker.C {
+			select {
+			case rl.tokens <- struct{}{}:
+			default:
+			}
+		}
+	}()
+	return rl
+}
+
+func (rl *rateLimiter) Wait() {
+	if rl == nil {
+		return
+	}
+	<-rl.tokens
+}
@@ -0,0 +1,131 @@
+package main
+
+import (
+	"io"
+	"net/http"
+	"regexp"
+	"strings"
+)
+
+const maxSinkBodyBytes = 512 * 1024
+
+// RedirectSink locates a candidate redirect target inside a response,
+// beyond the plain Location header.
+type RedirectSink interface {
+	Name() string
+	Locate(resp *http.Response, body []byte) (string, bool)
+}
+
+type locationHeaderSink struct{}
+
+func (locationHeaderSink) Name() string { return "location" }
+
+func (locationHeaderSink) Locate(resp *http.Response, body []byte) (string, bool) {
+	loc := resp.Header.Get("Location")
+	return loc, loc != ""
+}
+
+type refreshHeaderSink struct{}
+
+func (refreshHeaderSink) Name() string { return "refresh" }
+
+func (refreshHeaderSink) Locate(resp *http.Response, body []byte) (string, bool) {
+	return parseRefreshValue(resp.Header.Get("Refresh"))
+}
+
+type linkHeaderSink struct{}
+
+func (linkHeaderSink) Name() string { return "link" }
+
+func (linkHeaderSink) Locate(resp *http.Response, body []byte) (string, bool) {
+	link := resp.Header.Get("Link")
+	if link == "" {
+		return "", false
+	}
+	m := linkHeaderRe.FindStringSubmatch(link)
+	if m == nil {
+		return "", false
+	}
+	return m[1], true
+}
+
+var linkHeaderRe = regexp.MustCompile(`<([^>]+)>`)
+
+type metaRefreshSink struct{}
+
+func (metaRefreshSink) Name() string { return "meta" }
+
+func (metaRefreshSink) Locate(resp *http.Response, body []byte) (string, bool) {
+	m := metaRefreshRe.FindSubmatch(body)
+	if m == nil {
+		return "", false
+	}
+	return parseRefreshValue(string(m[1]))
+}
+
+var metaRefreshRe = regexp.MustCompile(`(?is)<meta[^>]+http-equiv\s*=\s*["']?refresh["']?[^>]+content\s*=\s*["']([^"']+)["']`)
+
+type windowLocationJSSink struct{}
+
+func (windowLocationJSSink) Name() string { return "js" }
+
+func (windowLocationJSSink) Locate(resp *http.Response, body []byte) (string, bool) {
+	m := windowLocationRe.FindSubmatch(body)
+	if m == nil {
+		return "", false
+	}
+	return string(m[1]), true
+}
+
+var windowLocationRe = regexp.MustCompile(`(?is)(?:window\.)?location(?:\.href)?\s*(?:=|\.replace\()\s*['"]([^'"]+)['"]`)
+
+// parseRefreshValue extracts the URL out of a "0;url=..." refresh value.
+func parseRefreshValue(value string) (string, bool) {
+	if value == "" {
+		return "", false
+	}
+	parts := strings.SplitN(value, ";", 2)
+	if len(parts) != 2 {
+		return "", false
+	}
+	u := strings.TrimSpace(parts[1])
+	if idx := strings.IndexByte(u, '='); idx != -1 {
+		u = u[idx+1:]
+	}
+	u = strings.Trim(u, `'"`)
+	if u == "" {
+		return "", false
+	}
+	return u, true
+}
+
+var allSinks = map[string]RedirectSink{
+	"location": locationHeaderSink{},
+	"refresh":  refreshHeaderSink{},
+	"meta":     metaRefreshSink{},
+	"js":       windowLocationJSSink{},
+	"link":     linkHeaderSink{},
+}
+
+// parseSinks turns a --sinks flag value into the set of active sinks,
+// defaulting to just the Location header when the flag is empty.
+func parseSinks(value string) []RedirectSink {
+	if value == "" {
+		return []RedirectSink{locationHeaderSink{}}
+	}
+	var out []RedirectSink
+	for _, name := range strings.Split(value, ",") {
+		name = strings.TrimSpace(name)
+		if s, ok := allSinks[name]; ok {
+			out = append(out, s)
+		}
+	}
+	return out
+}
+
+// readSinkBody reads a bounded prefix of the response body for the body-
+// parsing sinks (meta, js) to scan.
+func readSinkBody(resp *http.Response) []byte {
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, maxSinkBodyBytes))
+	return body
+}
@@ -0,0 +1,102 @@
+package main
+
+import (
+	"bufio"
+	"os"
+	"sync"
+)
+
+// stateFlushEvery controls how many appended lines accumulate before the
+// state file is fsync'd.
+const stateFlushEvery = 20
+
+// StateStore records every (url, param, payload) tuple already attempted
+// in an append-only file, so a rerun with the same --state-file skips
+// completed work instead of restarting the scan from scratch.
+type StateStore struct {
+	mu      sync.Mutex
+	seen    map[string]struct{}
+	f       *os.File
+	pending int
+}
+
+// OpenStateStore loads path's existing entries (if any) and opens it for
+// appending. An empty path yields an in-memory-only store.
+func OpenStateStore(path string) (*StateStore, error) {
+	s := &StateStore{seen: make(map[string]struct{})}
+	if path == "" {
+		return s, nil
+	}
+
+	if existing, err := os.Open(path); err == nil {
+		sc := bufio.NewScanner(existing)
+		for sc.Scan() {
+			s.seen[sc.Text()] = struct{}{}
+		}
+		existing.Close()
+		if err := sc.Err(); err != nil {
+			return nil, err
+		}
+	}
+
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return nil, err
+	}
+	s.f = f
+
+	return s, nil
+}
+
+func stateKey(url, param, payload string) string {
+	return url + "\x00" + param + "\x00" + payload
+}
+
+// Done reports whether (url, param, payload) was already attempted.
+func (s *StateStore) Done(url, param, payload string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	_, ok := s.seen[stateKey(url, param, payload)]
+	return ok
+}
+
+// Record marks (url, param, payload) as attempted, appending it to the
+// state file and fsyncing every stateFlushEvery lines.
+func (s *StateStore) Record(url, param, payload string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	key := stateKey(url, param, payload)
+	if _, ok := s.seen[key]; ok {
+		return nil
+	}
+	s.seen[key] = struct{}{}
+
+	if s.f == nil {
+		return nil
+	}
+
+	if _, err := s.f.WriteString(key + "\n"); err != nil {
+		return err
+	}
+
+	s.pending++
+	if s.pending >= stateFlushEvery {
+		s.pending = 0
+		return s.f.Sync()
+	}
+	return nil
+}
+
+// Close flushes and closes the backing state file, if any.
+func (s *StateStore) Close() error {
+	if s.f == nil {
+		return nil
+	}
+	if err := s.f.Sync(); err != nil {
+		s.f.Close()
+		return err
+	}
+	return s.f.Close()
+}